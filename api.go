@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// apiTitleRe is stricter than validPath's: titles must start with a letter
+// and stay within a sane length, since API clients don't get the implicit
+// sanitization the HTML forms apply.
+var apiTitleRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]{0,63}$`)
+
+type apiPage struct {
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+	Format  string `json:"format,omitempty"`
+	Updated string `json:"updated,omitempty"`
+}
+
+type apiPageSummary struct {
+	Title   string `json:"title"`
+	Size    int    `json:"size"`
+	Updated string `json:"updated,omitempty"`
+}
+
+type apiErrorBody struct {
+	Error string `json:"error"`
+}
+
+func writeAPIError(w http.ResponseWriter, status int, msg string) {
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiErrorBody{Error: msg})
+}
+
+func pageModTime(title string) string {
+	t, err := store.ModTime(title)
+	if err != nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// apiHandler sets the JSON content type every API response needs before
+// handing off to fn, mirroring how makeHandler wraps the HTML handlers.
+func apiHandler(fn http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fn(w, r)
+	}
+}
+
+func apiPagesHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	titles, err := store.List()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	summaries := make([]apiPageSummary, 0, len(titles))
+	for _, title := range titles {
+		body, _, err := store.Read(title)
+		if err != nil {
+			continue
+		}
+		summaries = append(summaries, apiPageSummary{
+			Title:   title,
+			Size:    len(body),
+			Updated: pageModTime(title),
+		})
+	}
+	json.NewEncoder(w).Encode(summaries)
+}
+
+func apiPageHandler(w http.ResponseWriter, r *http.Request) {
+	title := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/v1/pages/"), "/")
+	if title == "" {
+		writeAPIError(w, http.StatusBadRequest, "missing page title")
+		return
+	}
+	if !apiTitleRe.MatchString(title) {
+		writeAPIError(w, http.StatusBadRequest, "invalid page title")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		apiGetPage(w, title)
+	case http.MethodPut:
+		apiPutPage(w, r, title)
+	case http.MethodDelete:
+		apiDeletePage(w, title)
+	default:
+		writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func apiGetPage(w http.ResponseWriter, title string) {
+	p, err := loadPage(title)
+	if err != nil {
+		writeAPIError(w, http.StatusNotFound, "page not found")
+		return
+	}
+	json.NewEncoder(w).Encode(apiPage{Title: p.Title, Body: string(p.Body), Format: p.Format, Updated: pageModTime(title)})
+}
+
+func apiPutPage(w http.ResponseWriter, r *http.Request, title string) {
+	var req apiPage
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+	if req.Title != "" && req.Title != title {
+		writeAPIError(w, http.StatusConflict, "title in body does not match URL")
+		return
+	}
+
+	status := http.StatusOK
+	if !pageExists(title) {
+		status = http.StatusCreated
+	}
+
+	format := req.Format
+	if format != "md" {
+		format = "txt"
+	}
+	p := &Page{Title: title, Body: []byte(req.Body), Format: format}
+	if err := p.save(); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(apiPage{Title: title, Body: req.Body, Format: format, Updated: pageModTime(title)})
+}
+
+func apiDeletePage(w http.ResponseWriter, title string) {
+	if !pageExists(title) {
+		writeAPIError(w, http.StatusNotFound, "page not found")
+		return
+	}
+	if err := deletePage(title); err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,135 @@
+package main
+
+import "strings"
+
+type diffOp int
+
+const (
+	opEqual diffOp = iota
+	opDelete
+	opInsert
+)
+
+type diffLine struct {
+	Op   diffOp
+	Text string
+}
+
+// myersDiff computes the shortest edit script turning a into b using the
+// Myers O(ND) algorithm, so diffs are produced in-process rather than by
+// shelling out to an external diff tool.
+func myersDiff(a, b []string) []diffLine {
+	if len(a) == 0 && len(b) == 0 {
+		return nil
+	}
+	max := len(a) + len(b)
+	trace := shortestEdit(a, b, max)
+	return backtrack(a, b, trace, max)
+}
+
+func vIndex(k, max int) int { return k + max }
+
+// shortestEdit runs the forward pass of Myers' algorithm, recording the
+// furthest-reaching x value for every diagonal k at each edit distance d.
+func shortestEdit(a, b []string, max int) [][]int {
+	n, m := len(a), len(b)
+	v := make([]int, 2*max+1)
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		snapshot := make([]int, len(v))
+		copy(snapshot, v)
+		trace = append(trace, snapshot)
+
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[vIndex(k-1, max)] < v[vIndex(k+1, max)]) {
+				x = v[vIndex(k+1, max)]
+			} else {
+				x = v[vIndex(k-1, max)] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[vIndex(k, max)] = x
+			if x >= n && y >= m {
+				return trace
+			}
+		}
+	}
+	return trace
+}
+
+// backtrack walks the recorded traces from (len(a), len(b)) back to (0, 0),
+// emitting the edit script in forward order.
+func backtrack(a, b []string, trace [][]int, max int) []diffLine {
+	x, y := len(a), len(b)
+	var lines []diffLine
+
+	for d := len(trace) - 1; d > 0; d-- {
+		v := trace[d]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && v[vIndex(k-1, max)] < v[vIndex(k+1, max)]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := v[vIndex(prevK, max)]
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			lines = append(lines, diffLine{Op: opEqual, Text: a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			lines = append(lines, diffLine{Op: opInsert, Text: b[y-1]})
+		} else {
+			lines = append(lines, diffLine{Op: opDelete, Text: a[x-1]})
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		lines = append(lines, diffLine{Op: opEqual, Text: a[x-1]})
+		x--
+		y--
+	}
+
+	for i, j := 0, len(lines)-1; i < j; i, j = i+1, j-1 {
+		lines[i], lines[j] = lines[j], lines[i]
+	}
+	return lines
+}
+
+// splitLines splits s on "\n" the way a diff wants: zero lines for an empty
+// string, since strings.Split("", "\n") returns [""], which would otherwise
+// show up as a phantom blank line for a page that starts out, or ever was,
+// empty.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
+
+// unifiedDiff renders a line-level diff of a and b as plain text, one line
+// per diff line prefixed with "+ ", "- " or "  ".
+func unifiedDiff(a, b string) string {
+	lines := myersDiff(splitLines(a), splitLines(b))
+	var sb strings.Builder
+	for _, l := range lines {
+		switch l.Op {
+		case opInsert:
+			sb.WriteString("+ " + l.Text + "\n")
+		case opDelete:
+			sb.WriteString("- " + l.Text + "\n")
+		default:
+			sb.WriteString("  " + l.Text + "\n")
+		}
+	}
+	return sb.String()
+}
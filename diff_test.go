@@ -0,0 +1,15 @@
+package main
+
+import "testing"
+
+func TestUnifiedDiffEmptyBody(t *testing.T) {
+	if got := unifiedDiff("", ""); got != "" {
+		t.Errorf("unifiedDiff(%q, %q) = %q, want %q (no phantom context line)", "", "", got, "")
+	}
+
+	got := unifiedDiff("", "hello\nworld")
+	want := "+ hello\n+ world\n"
+	if got != want {
+		t.Errorf("unifiedDiff(%q, %q) = %q, want %q (no phantom delete-of-nothing line)", "", "hello\nworld", got, want)
+	}
+}
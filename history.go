@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// diffRow is one line of a rendered diff, tagged with how it should be
+// styled ("add", "del" or "ctx").
+type diffRow struct {
+	Op   string
+	Text string
+}
+
+func parseUnifiedDiff(data []byte) []diffRow {
+	text := strings.TrimRight(string(data), "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	rows := make([]diffRow, 0, len(lines))
+	for _, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "+ "):
+			rows = append(rows, diffRow{Op: "add", Text: line[2:]})
+		case strings.HasPrefix(line, "- "):
+			rows = append(rows, diffRow{Op: "del", Text: line[2:]})
+		default:
+			rows = append(rows, diffRow{Op: "ctx", Text: strings.TrimPrefix(line, "  ")})
+		}
+	}
+	return rows
+}
+
+func historyHandler(w http.ResponseWriter, r *http.Request, title string) {
+	revs, err := store.History(title)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Title     string
+		Revisions []Revision
+	}{Title: title, Revisions: revs}
+	renderTemplate(w, "history", data)
+}
+
+func diffHandler(w http.ResponseWriter, r *http.Request, title string) {
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = currentRevision
+	}
+
+	raw, err := store.Diff(title, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := struct {
+		Title    string
+		From, To string
+		Lines    []diffRow
+	}{Title: title, From: from, To: to, Lines: parseUnifiedDiff(raw)}
+	renderTemplate(w, "diff", data)
+}
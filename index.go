@@ -0,0 +1,371 @@
+package main
+
+import (
+	"encoding/gob"
+	"html/template"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Posting records where a token occurs within a single page.
+type Posting struct {
+	Title     string
+	Positions []int
+}
+
+var tokenRe = regexp.MustCompile(`[a-zA-Z0-9]+`)
+
+var stopwords = map[string]bool{
+	"the": true, "a": true, "an": true, "and": true, "or": true,
+	"of": true, "to": true, "in": true, "is": true, "it": true,
+	"for": true, "on": true, "with": true, "as": true, "by": true, "at": true,
+}
+
+// token is a single tokenized word together with its byte offset in the
+// source page body, so search results can build a snippet around a hit.
+type token struct {
+	text string
+	pos  int
+}
+
+func tokenize(body string) []token {
+	locs := tokenRe.FindAllStringIndex(body, -1)
+	tokens := make([]token, 0, len(locs))
+	for _, loc := range locs {
+		w := strings.ToLower(body[loc[0]:loc[1]])
+		if len(w) < 2 || stopwords[w] {
+			continue
+		}
+		tokens = append(tokens, token{text: w, pos: loc[0]})
+	}
+	return tokens
+}
+
+// Indexer maintains an in-memory inverted index over the .txt and .md pages
+// under dir, updated incrementally on save/delete and persisted to an
+// on-disk gob cache so restarts don't require a full walk.
+type Indexer struct {
+	mu        sync.RWMutex
+	dir       string
+	postings  map[string][]Posting // token -> postings, sorted by Title
+	docTokens map[string][]string  // title -> tokens present in that page
+}
+
+type indexCache struct {
+	DirModTime int64
+	Postings   map[string][]Posting
+	DocTokens  map[string][]string
+}
+
+func newIndexer(dir string) *Indexer {
+	idx := &Indexer{
+		dir:       dir,
+		postings:  make(map[string][]Posting),
+		docTokens: make(map[string][]string),
+	}
+	if !idx.loadCache() {
+		idx.rebuild()
+	}
+	return idx
+}
+
+func (idx *Indexer) cachePath() string {
+	return filepath.Join(idx.dir, ".index.cache")
+}
+
+func (idx *Indexer) loadCache() bool {
+	info, err := os.Stat(idx.dir)
+	if err != nil {
+		return false
+	}
+	f, err := os.Open(idx.cachePath())
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	var c indexCache
+	if err := gob.NewDecoder(f).Decode(&c); err != nil {
+		return false
+	}
+	if c.DirModTime != info.ModTime().Unix() {
+		return false
+	}
+	idx.postings = c.Postings
+	idx.docTokens = c.DocTokens
+	return true
+}
+
+func (idx *Indexer) saveCache() {
+	info, err := os.Stat(idx.dir)
+	if err != nil {
+		return
+	}
+	f, err := os.Create(idx.cachePath())
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	idx.mu.RLock()
+	c := indexCache{DirModTime: info.ModTime().Unix(), Postings: idx.postings, DocTokens: idx.docTokens}
+	idx.mu.RUnlock()
+	_ = gob.NewEncoder(f).Encode(c)
+}
+
+// readPage reads title's current body, trying every supported page format.
+func (idx *Indexer) readPage(title string) ([]byte, error) {
+	var lastErr error
+	for _, format := range pageFormats {
+		body, err := os.ReadFile(filepath.Join(idx.dir, title+"."+format))
+		if err == nil {
+			return body, nil
+		}
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// rebuild performs a full walk of dir, indexing every .txt and .md page.
+// It adds pages under a single lock and writes the cache once at the end,
+// rather than once per page, so a cold start on an N-page wiki does one
+// gob write instead of N.
+func (idx *Indexer) rebuild() {
+	entries, err := os.ReadDir(idx.dir)
+	if err != nil {
+		return
+	}
+	idx.mu.Lock()
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		if e.IsDir() || (ext != ".txt" && ext != ".md") {
+			continue
+		}
+		title := fileNameWithoutExtSliceNotation(e.Name())
+		body, err := os.ReadFile(filepath.Join(idx.dir, e.Name()))
+		if err != nil {
+			continue
+		}
+		idx.addPageLocked(title, body)
+	}
+	idx.mu.Unlock()
+
+	idx.saveCache()
+}
+
+// addPage (re)indexes title, replacing any postings left over from a
+// previous version of the page, and refreshes the on-disk cache.
+func (idx *Indexer) addPage(title string, body []byte) {
+	idx.mu.Lock()
+	idx.addPageLocked(title, body)
+	idx.mu.Unlock()
+
+	idx.saveCache()
+}
+
+// addPageLocked does the indexing work behind addPage without touching the
+// on-disk cache, so callers that add many pages at once (rebuild) can batch
+// the cache write themselves. idx.mu must be held by the caller.
+func (idx *Indexer) addPageLocked(title string, body []byte) {
+	idx.removeLocked(title)
+
+	byToken := make(map[string][]int)
+	for _, t := range tokenize(string(body)) {
+		byToken[t.text] = append(byToken[t.text], t.pos)
+	}
+
+	tokens := make([]string, 0, len(byToken))
+	for text, positions := range byToken {
+		tokens = append(tokens, text)
+		idx.insertPosting(text, Posting{Title: title, Positions: positions})
+	}
+	idx.docTokens[title] = tokens
+}
+
+// removePage drops title from the index entirely and refreshes the cache.
+func (idx *Indexer) removePage(title string) {
+	idx.mu.Lock()
+	idx.removeLocked(title)
+	idx.mu.Unlock()
+
+	idx.saveCache()
+}
+
+func (idx *Indexer) removeLocked(title string) {
+	for _, text := range idx.docTokens[title] {
+		postings := idx.postings[text]
+		for i, p := range postings {
+			if p.Title == title {
+				idx.postings[text] = append(postings[:i], postings[i+1:]...)
+				break
+			}
+		}
+		if len(idx.postings[text]) == 0 {
+			delete(idx.postings, text)
+		}
+	}
+	delete(idx.docTokens, title)
+}
+
+func (idx *Indexer) insertPosting(token string, p Posting) {
+	postings := idx.postings[token]
+	i := sort.Search(len(postings), func(i int) bool { return postings[i].Title >= p.Title })
+	postings = append(postings, Posting{})
+	copy(postings[i+1:], postings[i:])
+	postings[i] = p
+	idx.postings[token] = postings
+}
+
+// SearchResult is one ranked hit rendered by the search results template.
+// Hits is the raw term-frequency count shown to the user; score additionally
+// folds in the title-match boost and is used for ranking only.
+type SearchResult struct {
+	Title   string
+	Hits    int
+	Snippet template.HTML
+	score   int
+}
+
+// Search intersects the posting lists for every query term -- a page must
+// contain all of them to match at all -- then ranks the surviving pages by
+// term frequency plus a boost for title matches.
+func (idx *Indexer) Search(query string) []SearchResult {
+	terms := tokenize(query)
+	if len(terms) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var words []string
+	for _, t := range terms {
+		if !seen[t.text] {
+			seen[t.text] = true
+			words = append(words, t.text)
+		}
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	type hit struct {
+		term string
+		pos  int
+	}
+	tf := make(map[string]int)    // title -> raw term-frequency count, for display
+	first := make(map[string]hit) // title -> first matched term/position, for the snippet
+
+	titles := intersectPostings(idx.postings, words)
+	for _, title := range titles {
+		for _, w := range words {
+			for _, p := range idx.postings[w] {
+				if p.Title != title {
+					continue
+				}
+				tf[title] += len(p.Positions)
+				if _, ok := first[title]; !ok {
+					first[title] = hit{term: w, pos: p.Positions[0]}
+				}
+				break
+			}
+		}
+	}
+
+	results := make([]SearchResult, 0, len(titles))
+	for _, title := range titles {
+		score := tf[title]
+		if strings.Contains(strings.ToLower(title), words[0]) {
+			score += 5
+		}
+		body, err := idx.readPage(title)
+		var snippet template.HTML
+		if err == nil {
+			h := first[title]
+			snippet = buildSnippet(string(body), h.pos, len(h.term))
+		}
+		results = append(results, SearchResult{Title: title, Hits: tf[title], score: score, Snippet: snippet})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].score != results[j].score {
+			return results[i].score > results[j].score
+		}
+		return results[i].Title < results[j].Title
+	})
+	return results
+}
+
+// intersectPostings merges the sorted-by-title posting lists for every word
+// in words and returns only the titles present in all of them.
+func intersectPostings(postings map[string][]Posting, words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	current := make([]string, len(postings[words[0]]))
+	for i, p := range postings[words[0]] {
+		current[i] = p.Title
+	}
+	for _, w := range words[1:] {
+		current = intersectTitles(current, postings[w])
+		if len(current) == 0 {
+			return nil
+		}
+	}
+	return current
+}
+
+// intersectTitles merges sorted title list a against the sorted-by-title
+// postings b, a standard two-pointer merge since both sides are sorted.
+func intersectTitles(a []string, b []Posting) []string {
+	var out []string
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] < b[j].Title:
+			i++
+		case a[i] > b[j].Title:
+			j++
+		default:
+			out = append(out, a[i])
+			i++
+			j++
+		}
+	}
+	return out
+}
+
+// buildSnippet renders ±40 chars of context around pos with the matched
+// term wrapped in <mark>, HTML-escaping everything else.
+func buildSnippet(body string, pos, termLen int) template.HTML {
+	start := pos - 40
+	if start < 0 {
+		start = 0
+	}
+	end := pos + termLen + 40
+	if end > len(body) {
+		end = len(body)
+	}
+	if pos+termLen > len(body) {
+		return template.HTML(template.HTMLEscapeString(body[start:end]))
+	}
+
+	prefix := template.HTMLEscapeString(body[start:pos])
+	match := template.HTMLEscapeString(body[pos : pos+termLen])
+	suffix := template.HTMLEscapeString(body[pos+termLen : end])
+	return template.HTML(prefix + "<mark>" + match + "</mark>" + suffix)
+}
+
+// Titles returns every indexed page title, sorted alphabetically.
+func (idx *Indexer) Titles() []string {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	titles := make([]string, 0, len(idx.docTokens))
+	for title := range idx.docTokens {
+		titles = append(titles, title)
+	}
+	sort.Strings(titles)
+	return titles
+}
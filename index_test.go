@@ -0,0 +1,31 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSearchIntersectsTerms(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(dir+"/Apple.txt", []byte("Apple pie recipe with cinnamon and sugar."), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dir+"/Banana.txt", []byte("Banana bread recipe with walnuts and sugar."), 0600); err != nil {
+		t.Fatal(err)
+	}
+	idx := newIndexer(dir)
+
+	if results := idx.Search("apple walnuts"); len(results) != 0 {
+		t.Fatalf("Search(%q) = %v, want no results since no page contains both terms", "apple walnuts", results)
+	}
+
+	results := idx.Search("sugar")
+	if len(results) != 2 {
+		t.Fatalf("Search(%q) returned %d results, want 2", "sugar", len(results))
+	}
+	for _, r := range results {
+		if r.Hits != 1 {
+			t.Errorf("Search(%q) result %q has Hits=%d, want 1 (raw term frequency, no boost)", "sugar", r.Title, r.Hits)
+		}
+	}
+}
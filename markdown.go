@@ -0,0 +1,172 @@
+package main
+
+import (
+	"fmt"
+	"html/template"
+	"regexp"
+	"strings"
+)
+
+// inlineRe matches the inline Markdown constructs we support: code spans,
+// bold, italic, images and links. Plain text outside these matches is
+// HTML-escaped, and link/image URLs are passed through safeURL, so the
+// renderer never needs a separate HTML-parsing sanitization pass over its
+// own output.
+var inlineRe = regexp.MustCompile("`([^`]+)`" +
+	`|\*\*([^*]+)\*\*` +
+	`|\*([^*]+)\*` +
+	`|!\[([^\]]+)\]\(([^)]+)\)` +
+	`|\[([^\]]+)\]\(([^)]+)\)`)
+
+// controlCharRe matches any C0 control character, including tab and
+// newline. Browsers strip these out of a URL before resolving its scheme,
+// so "java\tscript:" must be rejected the same as "javascript:" or the
+// scheme check below can be smuggled past a stripped whitespace split.
+var controlCharRe = regexp.MustCompile("[\x00-\x1f]")
+
+// safeURL rejects javascript: and data: URLs, which would otherwise let a
+// page body smuggle a script into a link or image src.
+func safeURL(raw string) string {
+	stripped := controlCharRe.ReplaceAllString(raw, "")
+	lower := strings.ToLower(strings.TrimSpace(stripped))
+	if strings.HasPrefix(lower, "javascript:") || strings.HasPrefix(lower, "data:") {
+		return "#"
+	}
+	return raw
+}
+
+func inlineMarkdown(text string) string {
+	matches := inlineRe.FindAllStringSubmatchIndex(text, -1)
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		out.WriteString(template.HTMLEscapeString(text[last:m[0]]))
+		switch {
+		case m[2] != -1: // code
+			out.WriteString("<code>" + template.HTMLEscapeString(text[m[2]:m[3]]) + "</code>")
+		case m[4] != -1: // bold
+			out.WriteString("<strong>" + template.HTMLEscapeString(text[m[4]:m[5]]) + "</strong>")
+		case m[6] != -1: // italic
+			out.WriteString("<em>" + template.HTMLEscapeString(text[m[6]:m[7]]) + "</em>")
+		case m[8] != -1: // image
+			alt, src := text[m[8]:m[9]], text[m[10]:m[11]]
+			out.WriteString(`<img src="` + template.HTMLEscapeString(safeURL(src)) + `" alt="` + template.HTMLEscapeString(alt) + `">`)
+		case m[12] != -1: // link
+			label, url := text[m[12]:m[13]], text[m[14]:m[15]]
+			out.WriteString(`<a href="` + template.HTMLEscapeString(safeURL(url)) + `">` + template.HTMLEscapeString(label) + `</a>`)
+		}
+		last = m[1]
+	}
+	out.WriteString(template.HTMLEscapeString(text[last:]))
+	return out.String()
+}
+
+// headingLevel returns 1-6 if trimmed is an ATX heading ("# Title"), else 0.
+func headingLevel(trimmed string) int {
+	level := 0
+	for level < len(trimmed) && level < 6 && trimmed[level] == '#' {
+		level++
+	}
+	if level == 0 {
+		return 0
+	}
+	if level == len(trimmed) || trimmed[level] == ' ' {
+		return level
+	}
+	return 0
+}
+
+// orderedItemRe matches an ordered-list marker like "1. " or "12) ".
+var orderedItemRe = regexp.MustCompile(`^\d+[.)] `)
+
+func isBlockStart(trimmed string) bool {
+	return strings.HasPrefix(trimmed, "#") ||
+		strings.HasPrefix(trimmed, ">") ||
+		strings.HasPrefix(trimmed, "```") ||
+		strings.HasPrefix(trimmed, "- ") ||
+		strings.HasPrefix(trimmed, "* ") ||
+		orderedItemRe.MatchString(trimmed)
+}
+
+// renderMarkdown converts a practical subset of CommonMark (headings, fenced
+// code blocks, blockquotes, bullet and numbered lists, paragraphs, and the
+// inline forms handled by inlineMarkdown) into the sanitizer's allowlisted
+// tag set. It does not support raw inline HTML passthrough, which is what
+// keeps its output safe without a separate HTML-parsing sanitization step.
+func renderMarkdown(body []byte) template.HTML {
+	lines := strings.Split(string(body), "\n")
+	var out strings.Builder
+	listTag := "" // "ul", "ol", or "" when no list is open
+	closeList := func() {
+		if listTag != "" {
+			out.WriteString("</" + listTag + ">\n")
+			listTag = ""
+		}
+	}
+	openList := func(tag string) {
+		if listTag != tag {
+			closeList()
+			out.WriteString("<" + tag + ">\n")
+			listTag = tag
+		}
+	}
+
+	for i := 0; i < len(lines); {
+		trimmed := strings.TrimSpace(lines[i])
+
+		switch {
+		case strings.HasPrefix(trimmed, "```"):
+			closeList()
+			i++
+			start := i
+			for i < len(lines) && !strings.HasPrefix(strings.TrimSpace(lines[i]), "```") {
+				i++
+			}
+			code := strings.Join(lines[start:i], "\n")
+			out.WriteString("<pre><code>" + template.HTMLEscapeString(code) + "</code></pre>\n")
+			i++ // skip closing fence
+
+		case headingLevel(trimmed) > 0:
+			closeList()
+			level := headingLevel(trimmed)
+			text := strings.TrimSpace(trimmed[level:])
+			out.WriteString(fmt.Sprintf("<h%d>%s</h%d>\n", level, inlineMarkdown(text), level))
+			i++
+
+		case strings.HasPrefix(trimmed, ">"):
+			closeList()
+			var quote []string
+			for i < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[i]), ">") {
+				quote = append(quote, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[i]), ">")))
+				i++
+			}
+			out.WriteString("<blockquote><p>" + inlineMarkdown(strings.Join(quote, " ")) + "</p></blockquote>\n")
+
+		case strings.HasPrefix(trimmed, "- ") || strings.HasPrefix(trimmed, "* "):
+			openList("ul")
+			out.WriteString("<li>" + inlineMarkdown(trimmed[2:]) + "</li>\n")
+			i++
+
+		case orderedItemRe.MatchString(trimmed):
+			openList("ol")
+			marker := orderedItemRe.FindString(trimmed)
+			out.WriteString("<li>" + inlineMarkdown(trimmed[len(marker):]) + "</li>\n")
+			i++
+
+		case trimmed == "":
+			closeList()
+			i++
+
+		default:
+			closeList()
+			var para []string
+			for i < len(lines) && strings.TrimSpace(lines[i]) != "" && !isBlockStart(strings.TrimSpace(lines[i])) {
+				para = append(para, lines[i])
+				i++
+			}
+			out.WriteString("<p>" + inlineMarkdown(strings.Join(para, " ")) + "</p>\n")
+		}
+	}
+	closeList()
+	return template.HTML(out.String())
+}
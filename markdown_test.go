@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestSafeURLRejectsControlCharacterBypass(t *testing.T) {
+	cases := []string{
+		"javascript:alert(1)",
+		"java\tscript:alert(1)",
+		"java\nscript:alert(1)",
+		"  javascript:alert(1)  ",
+		"DATA:text/html,<script>alert(1)</script>",
+	}
+	for _, raw := range cases {
+		if got := safeURL(raw); got != "#" {
+			t.Errorf("safeURL(%q) = %q, want %q", raw, got, "#")
+		}
+	}
+
+	if got := safeURL("https://example.com"); got != "https://example.com" {
+		t.Errorf("safeURL(%q) = %q, want unchanged", "https://example.com", got)
+	}
+}
+
+func TestRenderMarkdownOrderedList(t *testing.T) {
+	got := string(renderMarkdown([]byte("1. foo\n2. bar\n")))
+	want := "<ol>\n<li>foo</li>\n<li>bar</li>\n</ol>\n"
+	if got != want {
+		t.Errorf("renderMarkdown ordered list = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"html/template"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// wikiLinkRe matches either an explicit [[PageName]] link or a bare
+// CamelCase WikiWord, the two forms of automatic interlinking.
+var wikiLinkRe = regexp.MustCompile(`\[\[([A-Za-z0-9_\- ]+)\]\]|\b([A-Z][a-z0-9]+(?:[A-Z][a-z0-9]+)+)\b`)
+
+func pageExists(title string) bool {
+	return store.Exists(title)
+}
+
+func linkTargets(body []byte) []string {
+	matches := wikiLinkRe.FindAllStringSubmatch(string(body), -1)
+	seen := make(map[string]bool)
+	var targets []string
+	for _, m := range matches {
+		target := m[1]
+		if target == "" {
+			target = m[2]
+		}
+		target = strings.ReplaceAll(target, " ", "_")
+		if !seen[target] {
+			seen[target] = true
+			targets = append(targets, target)
+		}
+	}
+	return targets
+}
+
+// linkify turns [[PageName]] and bare CamelCase words that name an existing
+// page into links, renders unknown targets as redlinks pointing at /edit,
+// and HTML-escapes everything else.
+func linkify(body []byte) template.HTML {
+	text := string(body)
+	matches := wikiLinkRe.FindAllStringSubmatchIndex(text, -1)
+
+	var out strings.Builder
+	last := 0
+	for _, m := range matches {
+		out.WriteString(template.HTMLEscapeString(text[last:m[0]]))
+
+		var label string
+		if m[2] != -1 {
+			label = text[m[2]:m[3]]
+		} else {
+			label = text[m[4]:m[5]]
+		}
+		target := strings.ReplaceAll(label, " ", "_")
+		escapedTarget := template.HTMLEscapeString(target)
+		escapedLabel := template.HTMLEscapeString(label)
+		if pageExists(target) {
+			out.WriteString(`<a href="/view/` + escapedTarget + `">` + escapedLabel + `</a>`)
+		} else {
+			out.WriteString(`<a class="missing" href="/edit/` + escapedTarget + `">` + escapedLabel + `</a>`)
+		}
+		last = m[1]
+	}
+	out.WriteString(template.HTMLEscapeString(text[last:]))
+	return template.HTML(out.String())
+}
+
+// linkGraph tracks which pages link to which, so backlinks can be looked up
+// without rescanning every page on each request.
+type linkGraph struct {
+	mu        sync.RWMutex
+	forward   map[string][]string        // title -> titles it links to
+	backlinks map[string]map[string]bool // title -> set of titles linking to it
+}
+
+var backlinkGraph = &linkGraph{
+	forward:   make(map[string][]string),
+	backlinks: make(map[string]map[string]bool),
+}
+
+// update replaces the set of outgoing links recorded for title, adjusting
+// the reverse backlink index to match.
+func (g *linkGraph) update(title string, targets []string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, old := range g.forward[title] {
+		delete(g.backlinks[old], title)
+	}
+	g.forward[title] = targets
+	for _, target := range targets {
+		if g.backlinks[target] == nil {
+			g.backlinks[target] = make(map[string]bool)
+		}
+		g.backlinks[target][title] = true
+	}
+}
+
+func (g *linkGraph) remove(title string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, old := range g.forward[title] {
+		delete(g.backlinks[old], title)
+	}
+	delete(g.forward, title)
+	delete(g.backlinks, title)
+}
+
+// rebuild scans every page under dir and populates the link graph from
+// scratch; it's run once at startup since updates after that are incremental.
+func (g *linkGraph) rebuild(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if e.IsDir() || (!strings.HasSuffix(e.Name(), ".txt") && !strings.HasSuffix(e.Name(), ".md")) {
+			continue
+		}
+		title := fileNameWithoutExtSliceNotation(e.Name())
+		body, err := os.ReadFile(dir + "/" + e.Name())
+		if err != nil {
+			continue
+		}
+		g.update(title, linkTargets(body))
+	}
+}
+
+// Backlinks returns the titles of pages that link to title, sorted.
+func (g *linkGraph) Backlinks(title string) []string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	set := g.backlinks[title]
+	titles := make([]string, 0, len(set))
+	for t := range set {
+		titles = append(titles, t)
+	}
+	sort.Strings(titles)
+	return titles
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// currentRevision is the Revision.ID meaning "the live body of the page",
+// as opposed to a snapshot taken before some earlier overwrite.
+const currentRevision = "current"
+
+// pageFormats lists the extensions (without the dot) that back a page,
+// in the order resolveFormat should prefer when more than one is present.
+var pageFormats = []string{"md", "txt"}
+
+// Revision describes a single saved version of a page.
+type Revision struct {
+	ID      string
+	Author  string
+	Message string
+	Time    time.Time
+}
+
+// Storage abstracts how pages are persisted, so the wiki can run against a
+// plain filesystem or a git-backed history without the handlers caring
+// which. Read/Write carry a format ("md" or "txt") alongside the body so a
+// page's content type survives storage round-trips. Exists/ModTime let
+// callers like the link graph and the REST API ask about a page without
+// reaching past the interface into the filesystem themselves.
+type Storage interface {
+	Read(title string) (body []byte, format string, err error)
+	Write(title string, body []byte, format, author, msg string) error
+	Delete(title, author, msg string) error
+	List() ([]string, error)
+	Exists(title string) bool
+	ModTime(title string) (time.Time, error)
+	History(title string) ([]Revision, error)
+	Diff(title, revA, revB string) ([]byte, error)
+}
+
+// fsStorage stores each page as a plain .md or .txt file. Every overwrite
+// snapshots the previous body under dir/.history/<title>/<unix-nano>.snap,
+// which is what backs History and Diff.
+type fsStorage struct {
+	dir string
+}
+
+func newFSStorage(dir string) *fsStorage {
+	return &fsStorage{dir: dir}
+}
+
+func (s *fsStorage) path(title, format string) string {
+	return filepath.Join(s.dir, title+"."+format)
+}
+
+func (s *fsStorage) historyDir(title string) string {
+	return filepath.Join(s.dir, ".history", title)
+}
+
+// resolveFormat finds which extension title is currently stored under.
+func (s *fsStorage) resolveFormat(title string) (string, error) {
+	for _, format := range pageFormats {
+		if _, err := os.Stat(s.path(title, format)); err == nil {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("page %q not found", title)
+}
+
+func (s *fsStorage) Read(title string) ([]byte, string, error) {
+	format, err := s.resolveFormat(title)
+	if err != nil {
+		return nil, "", err
+	}
+	body, err := os.ReadFile(s.path(title, format))
+	if err != nil {
+		return nil, "", err
+	}
+	return body, format, nil
+}
+
+func (s *fsStorage) Write(title string, body []byte, format, author, msg string) error {
+	_, err := s.write(title, body, format, true)
+	return err
+}
+
+// write is the shared implementation behind fsStorage.Write and
+// gitStorage.Write. When snapshot is true (plain filesystem storage, which
+// has no other history mechanism) it snapshots the old body before
+// overwriting; gitStorage passes false since git itself is the history and
+// a .history/ snapshot nobody reads would just be wasted writes. It returns
+// the format the page was previously stored under, if any, so callers that
+// need to know whether the format changed (gitStorage, to stage the old
+// path too) don't have to resolve it twice.
+func (s *fsStorage) write(title string, body []byte, format string, snapshot bool) (oldFormat string, err error) {
+	if format == "" {
+		format = "txt"
+	}
+	if oldBody, of, err := s.Read(title); err == nil {
+		oldFormat = of
+		if snapshot {
+			if err := s.snapshot(title, oldBody); err != nil {
+				return oldFormat, err
+			}
+		}
+		if oldFormat != format {
+			if err := os.Remove(s.path(title, oldFormat)); err != nil {
+				return oldFormat, err
+			}
+		}
+	}
+	return oldFormat, os.WriteFile(s.path(title, format), body, 0600)
+}
+
+func (s *fsStorage) snapshot(title string, body []byte) error {
+	dir := s.historyDir(title)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := strconv.FormatInt(time.Now().UnixNano(), 10) + ".snap"
+	return os.WriteFile(filepath.Join(dir, name), body, 0600)
+}
+
+func (s *fsStorage) Delete(title, author, msg string) error {
+	format, err := s.resolveFormat(title)
+	if err != nil {
+		return err
+	}
+	return os.Remove(s.path(title, format))
+}
+
+func (s *fsStorage) Exists(title string) bool {
+	_, err := s.resolveFormat(title)
+	return err == nil
+}
+
+func (s *fsStorage) ModTime(title string) (time.Time, error) {
+	format, err := s.resolveFormat(title)
+	if err != nil {
+		return time.Time{}, err
+	}
+	info, err := os.Stat(s.path(title, format))
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+func (s *fsStorage) List() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	var titles []string
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := strings.TrimPrefix(filepath.Ext(e.Name()), ".")
+		if ext != "md" && ext != "txt" {
+			continue
+		}
+		title := fileNameWithoutExtSliceNotation(e.Name())
+		if !seen[title] {
+			seen[title] = true
+			titles = append(titles, title)
+		}
+	}
+	return titles, nil
+}
+
+func (s *fsStorage) History(title string) ([]Revision, error) {
+	entries, err := os.ReadDir(s.historyDir(title))
+	var revs []Revision
+	if err == nil {
+		for _, e := range entries {
+			if e.IsDir() {
+				continue
+			}
+			id := fileNameWithoutExtSliceNotation(e.Name())
+			nanos, convErr := strconv.ParseInt(id, 10, 64)
+			if convErr != nil {
+				continue
+			}
+			revs = append(revs, Revision{ID: id, Message: "revision", Time: time.Unix(0, nanos)})
+		}
+	}
+	sort.Slice(revs, func(i, j int) bool { return revs[i].Time.Before(revs[j].Time) })
+
+	if format, err := s.resolveFormat(title); err == nil {
+		if info, err := os.Stat(s.path(title, format)); err == nil {
+			revs = append(revs, Revision{ID: currentRevision, Message: "current", Time: info.ModTime()})
+		}
+	}
+	return revs, nil
+}
+
+func (s *fsStorage) contentAt(title, rev string) ([]byte, error) {
+	if rev == "" || rev == currentRevision {
+		body, _, err := s.Read(title)
+		return body, err
+	}
+	return os.ReadFile(filepath.Join(s.historyDir(title), rev+".snap"))
+}
+
+func (s *fsStorage) Diff(title, revA, revB string) ([]byte, error) {
+	a, err := s.contentAt(title, revA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.contentAt(title, revB)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(unifiedDiff(string(a), string(b))), nil
+}
+
+// gitStorage persists pages the same way fsStorage does, but commits every
+// write and delete to a git repository rooted at dir, so History and Diff
+// can be served straight out of the git log. It skips fsStorage's
+// .history/ snapshotting entirely -- git is already the history here, so a
+// snapshot nothing ever reads would just be wasted writes.
+type gitStorage struct {
+	*fsStorage
+}
+
+func newGitStorage(dir string) *gitStorage {
+	return &gitStorage{fsStorage: newFSStorage(dir)}
+}
+
+func (s *gitStorage) git(args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", s.dir}, args...)...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	if err := cmd.Run(); err != nil {
+		return out.String(), fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, out.String())
+	}
+	return out.String(), nil
+}
+
+func formatAuthor(author string) string {
+	if author == "" {
+		return "wiki <wiki@localhost>"
+	}
+	if strings.Contains(author, "<") {
+		return author
+	}
+	return author + " <" + author + "@localhost>"
+}
+
+// changedPaths returns the on-disk filename(s) a write to title touched: the
+// old format's path too when the format changed, so `git add -A` is only
+// ever asked about paths that actually existed or now exist, never the full
+// pageFormats list regardless of which extension is in play.
+func changedPaths(title, oldFormat, newFormat string) []string {
+	paths := []string{title + "." + newFormat}
+	if oldFormat != "" && oldFormat != newFormat {
+		paths = append(paths, title+"."+oldFormat)
+	}
+	return paths
+}
+
+// isNothingToCommit reports whether err is git's refusal to create an empty
+// commit, which gitStorage treats as a successful no-op rather than an
+// error: e.g. resaving a page with byte-identical content stages nothing,
+// and that's fine.
+func isNothingToCommit(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "nothing to commit")
+}
+
+func (s *gitStorage) Write(title string, body []byte, format, author, msg string) error {
+	oldFormat, err := s.fsStorage.write(title, body, format, false)
+	if err != nil {
+		return err
+	}
+	if format == "" {
+		format = "txt"
+	}
+	paths := changedPaths(title, oldFormat, format)
+	if _, err := s.git(append([]string{"add", "-A", "--"}, paths...)...); err != nil {
+		return err
+	}
+	if msg == "" {
+		msg = "update " + title
+	}
+	_, err = s.git(append([]string{"commit", "--author", formatAuthor(author), "-m", msg, "--"}, paths...)...)
+	if isNothingToCommit(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *gitStorage) Delete(title, author, msg string) error {
+	format, err := s.resolveFormat(title)
+	if err != nil {
+		return err
+	}
+	filename := title + "." + format
+	if _, err := s.git("rm", "--", filename); err != nil {
+		return err
+	}
+	if msg == "" {
+		msg = "delete " + title
+	}
+	_, err = s.git("commit", "--author", formatAuthor(author), "-m", msg, "--", filename)
+	if isNothingToCommit(err) {
+		return nil
+	}
+	return err
+}
+
+func (s *gitStorage) History(title string) ([]Revision, error) {
+	filename := title + ".txt"
+	if format, err := s.resolveFormat(title); err == nil {
+		filename = title + "." + format
+	}
+	out, err := s.git("log", "--follow", "--format=%H%x1f%an%x1f%ct%x1f%s", "--", filename)
+	if err != nil {
+		return nil, err
+	}
+	var revs []Revision
+	for _, line := range strings.Split(strings.TrimRight(out, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		fields := strings.Split(line, "\x1f")
+		if len(fields) != 4 {
+			continue
+		}
+		ts, _ := strconv.ParseInt(fields[2], 10, 64)
+		revs = append(revs, Revision{
+			ID:      fields[0],
+			Author:  fields[1],
+			Time:    time.Unix(ts, 0),
+			Message: fields[3],
+		})
+	}
+	return revs, nil
+}
+
+func (s *gitStorage) contentAt(title, rev string) ([]byte, error) {
+	if rev == "" || rev == currentRevision {
+		body, _, err := s.Read(title)
+		return body, err
+	}
+	filename := title + ".txt"
+	if format, err := s.resolveFormat(title); err == nil {
+		filename = title + "." + format
+	}
+	out, err := s.git("show", rev+":"+filename)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// Diff fetches both revisions' content via git and diffs them with the
+// package's own Myers implementation, rather than shelling out to `git
+// diff`, so the rendered diff is consistent across storage backends.
+func (s *gitStorage) Diff(title, revA, revB string) ([]byte, error) {
+	a, err := s.contentAt(title, revA)
+	if err != nil {
+		return nil, err
+	}
+	b, err := s.contentAt(title, revB)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(unifiedDiff(string(a), string(b))), nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os/exec"
+	"testing"
+)
+
+func newTestGitStorage(t *testing.T) *gitStorage {
+	t.Helper()
+	dir := t.TempDir()
+	cmd := exec.Command("git", "init", dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Skipf("git init unavailable, skipping git storage test: %v: %s", err, out)
+	}
+	return newGitStorage(dir)
+}
+
+func TestGitStorageWriteSingleFormat(t *testing.T) {
+	s := newTestGitStorage(t)
+
+	if err := s.Write("Foo", []byte("hello"), "txt", "", ""); err != nil {
+		t.Fatalf("Write (create) with only one format on disk: %v", err)
+	}
+	if err := s.Write("Foo", []byte("hello again"), "txt", "", ""); err != nil {
+		t.Fatalf("Write (update) with only one format on disk: %v", err)
+	}
+
+	body, format, err := s.Read("Foo")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(body) != "hello again" || format != "txt" {
+		t.Fatalf("Read = (%q, %q), want (%q, %q)", body, format, "hello again", "txt")
+	}
+}
+
+func TestGitStorageWriteFormatChange(t *testing.T) {
+	s := newTestGitStorage(t)
+
+	if err := s.Write("Foo", []byte("hello"), "txt", "", ""); err != nil {
+		t.Fatalf("Write (create as txt): %v", err)
+	}
+	if err := s.Write("Foo", []byte("# hello"), "md", "", ""); err != nil {
+		t.Fatalf("Write (switch to md): %v", err)
+	}
+
+	body, format, err := s.Read("Foo")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(body) != "# hello" || format != "md" {
+		t.Fatalf("Read = (%q, %q), want (%q, %q)", body, format, "# hello", "md")
+	}
+}
+
+func TestGitStorageWriteIdenticalContentIsNoOp(t *testing.T) {
+	s := newTestGitStorage(t)
+
+	if err := s.Write("Foo", []byte("hello"), "txt", "", ""); err != nil {
+		t.Fatalf("Write (create): %v", err)
+	}
+	if err := s.Write("Foo", []byte("hello"), "txt", "", ""); err != nil {
+		t.Fatalf("Write (resave identical content) = %v, want nil (nothing-to-commit is a no-op)", err)
+	}
+
+	body, format, err := s.Read("Foo")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if string(body) != "hello" || format != "txt" {
+		t.Fatalf("Read = (%q, %q), want (%q, %q)", body, format, "hello", "txt")
+	}
+}
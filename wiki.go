@@ -1,49 +1,77 @@
 package main
 
 import (
+	"flag"
 	"html/template"
 	"log"
 	"net/http"
-	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 )
 
-var validPath = regexp.MustCompile("^/(edit|save|view|delete)/([a-zA-Z0-9-_]+)$")
+var validPath = regexp.MustCompile("^/(edit|save|view|delete|backlinks|history|diff)/([a-zA-Z0-9-_]+)$")
+
+// pageIndex is the in-memory full-text index over pages/, built at startup
+// and kept up to date as pages are saved or deleted.
+var pageIndex *Indexer
+
+// store is the configured Storage backend, selected by the -storage flag.
+var store Storage
 
 type Page struct {
-	Title string
-	Body  []byte
+	Title  string
+	Body   []byte
+	Format string // "txt" or "md"
 }
 
 func (p *Page) save() error {
-	filename := p.Title + ".txt"
-	return os.WriteFile("pages/"+filename, p.Body, 0600)
+	format := p.Format
+	if format == "" {
+		format = "txt"
+	}
+	if err := store.Write(p.Title, p.Body, format, "", ""); err != nil {
+		return err
+	}
+	if pageIndex != nil {
+		pageIndex.addPage(p.Title, p.Body)
+	}
+	backlinkGraph.update(p.Title, linkTargets(p.Body))
+	return nil
 }
 
 func loadPage(title string) (*Page, error) {
-	filename := title + ".txt"
-	body, err := os.ReadFile("pages/" + filename)
+	body, format, err := store.Read(title)
 	if err != nil {
 		return nil, err
 	}
-	return &Page{Title: title, Body: body}, nil
+	return &Page{Title: title, Body: body, Format: format}, nil
 }
 
-func renderTemplate(w http.ResponseWriter, tmpl string, p *Page) {
+// renderTemplate parses tmpl (plus the shared base layout) and executes it
+// against data, writing a 500 if either step fails. It's the one place that
+// builds a *template.Template, so every handler shares the same funcs and
+// the same "base"/ParseFiles convention instead of copy-pasting it.
+func renderTemplate(w http.ResponseWriter, tmpl string, data any) {
 	var templates = template.Must(template.New(tmpl).Funcs(template.FuncMap{
 		"safeHTML": func(b []byte) template.HTML {
 			return template.HTML(b)
 		},
 	}).ParseFiles("./templates/_base.html", "./templates/"+tmpl+".html"))
 
-	err := templates.ExecuteTemplate(w, "base", p)
-	if err != nil {
+	if err := templates.ExecuteTemplate(w, "base", data); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 	}
 }
 
+// viewData is the template data for view.html: the page rendered with
+// automatic interlinking applied, plus the pages that link back to it.
+type viewData struct {
+	Title     string
+	Body      template.HTML
+	Backlinks []string
+}
+
 func viewHandler(w http.ResponseWriter, r *http.Request, title string) {
 	p, err := loadPage(title)
 	if err != nil {
@@ -51,25 +79,34 @@ func viewHandler(w http.ResponseWriter, r *http.Request, title string) {
 		return
 	}
 
-	renderTemplate(w, "view", p)
+	var body template.HTML
+	if p.Format == "md" {
+		body = renderMarkdown(p.Body)
+	} else {
+		body = template.HTML("<pre>" + string(linkify(p.Body)) + "</pre>")
+	}
+
+	data := viewData{
+		Title:     p.Title,
+		Body:      body,
+		Backlinks: backlinkGraph.Backlinks(title),
+	}
+	renderTemplate(w, "view", data)
+}
+
+func backlinksHandler(w http.ResponseWriter, r *http.Request, title string) {
+	data := viewData{Title: title, Backlinks: backlinkGraph.Backlinks(title)}
+	renderTemplate(w, "backlinks", data)
 }
 
 func newHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "GET" {
-		var templates = template.Must(template.New("new").Funcs(template.FuncMap{
-			"safeHTML": func(b []byte) template.HTML {
-				return template.HTML(b)
-			},
-		}).ParseFiles("./templates/_base.html", "./templates/new.html"))
-		err := templates.ExecuteTemplate(w, "base", nil)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
-		}
+		renderTemplate(w, "new", nil)
 	} else {
 		title := r.FormValue("title")
 		title = strings.ReplaceAll(title, " ", "_")
 		body := r.FormValue("body")
-		p := &Page{Title: title, Body: []byte(body)}
+		p := &Page{Title: title, Body: []byte(body), Format: pageFormat(r)}
 		err := p.save()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -79,27 +116,61 @@ func newHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// pageFormat reads the new/edit form's format selector, defaulting to "txt"
+// for anything other than an explicit "md".
+func pageFormat(r *http.Request) string {
+	if r.FormValue("format") == "md" {
+		return "md"
+	}
+	return "txt"
+}
+
 func editHandler(w http.ResponseWriter, r *http.Request, title string) {
 	p, err := loadPage(title)
 	if err != nil {
-		p = &Page{Title: title}
+		p = &Page{Title: title, Format: "txt"}
 	}
 	renderTemplate(w, "edit", p)
 }
 
+// deletePage removes title from storage and both in-memory indexes; it's
+// the shared operation behind both deleteHandler and the REST API.
+func deletePage(title string) error {
+	if err := store.Delete(title, "", ""); err != nil {
+		return err
+	}
+	if pageIndex != nil {
+		pageIndex.removePage(title)
+	}
+	backlinkGraph.remove(title)
+	return nil
+}
+
 func deleteHandler(w http.ResponseWriter, r *http.Request, title string) {
-	filename := title + ".txt"
-	e := os.Remove("pages/" + filename)
-	if e != nil {
-		log.Fatal(e)
+	if err := deletePage(title); err != nil {
+		log.Fatal(err)
 	}
 	http.Redirect(w, r, "/", http.StatusFound)
 }
 
+func searchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("q")
+	var results []SearchResult
+	if pageIndex != nil {
+		results = pageIndex.Search(query)
+	}
+
+	data := struct {
+		Query   string
+		Results []SearchResult
+	}{Query: query, Results: results}
+	renderTemplate(w, "search", data)
+}
+
 func saveHandler(w http.ResponseWriter, r *http.Request, title string) {
 	body := r.FormValue("body")
 	title = strings.ReplaceAll(title, " ", "_")
-	p := &Page{Title: title, Body: []byte(body)}
+	p := &Page{Title: title, Body: []byte(body), Format: pageFormat(r)}
 	err := p.save()
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -120,12 +191,12 @@ func makeHandler(fn func(http.ResponseWriter, *http.Request, string)) http.Handl
 }
 
 func homeHandler(w http.ResponseWriter, r *http.Request) {
-	files := checkExt(".txt")
-
 	var data []*Page
-	for _, v := range files {
-		title := fileNameWithoutExtSliceNotation(v)
-		p, _ := loadPage(title)
+	for _, title := range pageIndex.Titles() {
+		p, err := loadPage(title)
+		if err != nil {
+			continue
+		}
 		data = append(data, p)
 	}
 
@@ -134,43 +205,29 @@ func homeHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var templates = template.Must(template.New("home").Funcs(template.FuncMap{
-		"safeHTML": func(b []byte) template.HTML {
-			return template.HTML(b)
-		},
-	}).ParseFiles("./templates/_base.html", "./templates/home.html"))
-	err := templates.ExecuteTemplate(w, "base", data)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-	}
+	renderTemplate(w, "home", data)
 }
 
 func fileNameWithoutExtSliceNotation(fileName string) string {
 	return fileName[:len(fileName)-len(filepath.Ext(fileName))]
 }
 
-func checkExt(ext string) []string {
-	pathS, err := os.Getwd()
-	if err != nil {
-		panic(err)
-	}
-	var files []string
-	err = filepath.Walk(pathS, func(path string, f os.FileInfo, _ error) error {
-		if !f.IsDir() {
-			r, err := regexp.MatchString(ext, f.Name())
-			if err == nil && r {
-				files = append(files, f.Name())
-			}
-		}
-		return nil
-	})
-	if err != nil {
-		return nil
+func main() {
+	storageKind := flag.String("storage", "fs", "storage backend to use: fs or git")
+	flag.Parse()
+
+	switch *storageKind {
+	case "git":
+		store = newGitStorage("pages")
+	case "fs":
+		store = newFSStorage("pages")
+	default:
+		log.Fatalf("unknown -storage %q: want fs or git", *storageKind)
 	}
-	return files
-}
 
-func main() {
+	pageIndex = newIndexer("pages")
+	backlinkGraph.rebuild("pages")
+
 	log.Println("Server started on: http://localhost:8080")
 	http.HandleFunc("/", homeHandler)
 	http.HandleFunc("/delete/", makeHandler(deleteHandler))
@@ -178,6 +235,12 @@ func main() {
 	http.HandleFunc("/new", newHandler)
 	http.HandleFunc("/edit/", makeHandler(editHandler))
 	http.HandleFunc("/save/", makeHandler(saveHandler))
+	http.HandleFunc("/search", searchHandler)
+	http.HandleFunc("/backlinks/", makeHandler(backlinksHandler))
+	http.HandleFunc("/history/", makeHandler(historyHandler))
+	http.HandleFunc("/diff/", makeHandler(diffHandler))
+	http.HandleFunc("/api/v1/pages", apiHandler(apiPagesHandler))
+	http.HandleFunc("/api/v1/pages/", apiHandler(apiPageHandler))
 
 	log.Fatal(http.ListenAndServe(":8080", nil))
 }